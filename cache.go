@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// usageCache is the on-disk record saved to $XDG_CACHE_HOME/claude-usage/usage.json.
+type usageCache struct {
+	Usage     *UsageResponse `json:"usage"`
+	FetchedAt time.Time      `json:"fetched_at"`
+	ETag      string         `json:"etag"`
+	TokenHash string         `json:"token_hash"`
+}
+
+func cacheFilePath() (string, error) {
+	dir := os.Getenv("XDG_CACHE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("could not determine home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(dir, "claude-usage", "usage.json"), nil
+}
+
+// tokenHash fingerprints an access token so switching Claude accounts
+// invalidates the cache automatically, without storing the token itself.
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+func loadCache() (*usageCache, error) {
+	path, err := cacheFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c usageCache
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse usage cache: %w", err)
+	}
+	return &c, nil
+}
+
+func saveCache(c *usageCache) error {
+	path, err := cacheFilePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage cache: %w", err)
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
+
+// clearCache removes the on-disk cache, backing the `claude-usage cache
+// clear` subcommand.
+func clearCache() error {
+	path, err := cacheFilePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove usage cache: %w", err)
+	}
+	return nil
+}
+
+// fetchUsageConditional calls the usage API with If-None-Match: etag,
+// returning notModified=true on a 304 so the caller can reuse its cached
+// body instead of re-parsing it.
+func fetchUsageConditional(ctx context.Context, url, token, etag string) (usage *UsageResponse, newETag string, notModified bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("anthropic-beta", "oauth-2025-04-20")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("failed to fetch usage: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, resp.Header.Get("ETag"), true, nil
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, "", false, fmt.Errorf("%w: %s", errUnauthorized, string(body))
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, "", false, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if err := json.Unmarshal(body, &usage); err != nil {
+		return nil, "", false, fmt.Errorf("failed to parse usage response: %w", err)
+	}
+
+	return usage, resp.Header.Get("ETag"), false, nil
+}
+
+// fetchUsageCached serves the on-disk cache when it's fresher than
+// minRefresh or still valid per ETag, and otherwise calls the API and
+// refreshes the cache.
+func fetchUsageCached(ctx context.Context, token string, minRefresh time.Duration, noCache bool) (*UsageResponse, error) {
+	return fetchUsageCachedFromURL(ctx, usageAPIURL, token, minRefresh, noCache)
+}
+
+func fetchUsageCachedFromURL(ctx context.Context, url, token string, minRefresh time.Duration, noCache bool) (*UsageResponse, error) {
+	if noCache {
+		return fetchUsageFromURL(ctx, url, token)
+	}
+
+	hash := tokenHash(token)
+	cached, _ := loadCache()
+	sameAccount := cached != nil && cached.TokenHash == hash
+
+	if sameAccount && time.Since(cached.FetchedAt) < minRefresh {
+		return cached.Usage, nil
+	}
+
+	etag := ""
+	if sameAccount {
+		etag = cached.ETag
+	}
+
+	usage, newETag, notModified, err := fetchUsageConditional(ctx, url, token, etag)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if notModified {
+		if !sameAccount {
+			return nil, fmt.Errorf("server returned 304 Not Modified for a request that sent no matching If-None-Match")
+		}
+		cached.FetchedAt = now
+		_ = saveCache(cached)
+		return cached.Usage, nil
+	}
+
+	_ = saveCache(&usageCache{Usage: usage, FetchedAt: now, ETag: newETag, TokenHash: hash})
+	return usage, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFetchUsageCachedConditionalRequest(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.Header().Set("ETag", `"v1"`)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"five_hour":{"utilization":10,"resets_at":"2026-01-09T15:00:00Z"},"seven_day":{"utilization":20,"resets_at":"2026-01-16T00:00:00Z"}}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	usage, err := fetchUsageCachedFromURL(ctx, server.URL, "test-token", 0, false)
+	if err != nil {
+		t.Fatalf("first fetch failed: %v", err)
+	}
+	if usage.FiveHour.Utilization != 10 {
+		t.Errorf("expected FiveHour.Utilization 10, got %v", usage.FiveHour.Utilization)
+	}
+	if requests != 1 {
+		t.Fatalf("expected 1 request after first fetch, got %d", requests)
+	}
+
+	cachedBefore, err := loadCache()
+	if err != nil {
+		t.Fatalf("failed to load cache after first fetch: %v", err)
+	}
+	fetchedAtBefore := cachedBefore.FetchedAt
+
+	usage, err = fetchUsageCachedFromURL(ctx, server.URL, "test-token", 0, false)
+	if err != nil {
+		t.Fatalf("second fetch failed: %v", err)
+	}
+	if requests != 2 {
+		t.Fatalf("expected 2 requests after second fetch (a 304), got %d", requests)
+	}
+	if usage.FiveHour.Utilization != 10 {
+		t.Errorf("expected cached body to be reused, got FiveHour.Utilization %v", usage.FiveHour.Utilization)
+	}
+
+	cachedAfter, err := loadCache()
+	if err != nil {
+		t.Fatalf("failed to load cache after second fetch: %v", err)
+	}
+	if !cachedAfter.FetchedAt.After(fetchedAtBefore) {
+		t.Errorf("expected fetched_at to advance on a 304, before=%v after=%v", fetchedAtBefore, cachedAfter.FetchedAt)
+	}
+}
+
+func TestFetchUsageCachedRejectsUnmatched304(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A non-compliant server returning 304 even though we sent no
+		// If-None-Match (there's no cache yet, so sameAccount is false).
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer server.Close()
+
+	_, err := fetchUsageCachedFromURL(context.Background(), server.URL, "test-token", 0, false)
+	if err == nil {
+		t.Fatal("expected an error for a 304 with no matching cache entry, got nil")
+	}
+}
+
+func TestFetchUsageCachedServesWithinMinRefresh(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"five_hour":{"utilization":5,"resets_at":"2026-01-09T15:00:00Z"},"seven_day":{"utilization":6,"resets_at":"2026-01-16T00:00:00Z"}}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	if _, err := fetchUsageCachedFromURL(ctx, server.URL, "test-token", time.Minute, false); err != nil {
+		t.Fatalf("first fetch failed: %v", err)
+	}
+	if _, err := fetchUsageCachedFromURL(ctx, server.URL, "test-token", time.Minute, false); err != nil {
+		t.Fatalf("second fetch failed: %v", err)
+	}
+
+	if requests != 1 {
+		t.Errorf("expected the second call inside minRefresh to be served from cache, got %d requests", requests)
+	}
+}
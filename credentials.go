@@ -1,17 +1,158 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 // Credentials represents the structure of credentials JSON
 type Credentials struct {
 	ClaudeAIOAuth struct {
-		AccessToken string `json:"accessToken"`
+		AccessToken  string `json:"accessToken"`
+		RefreshToken string `json:"refreshToken"`
+		ExpiresAt    int64  `json:"expiresAt"`
 	} `json:"claudeAiOauth"`
+
+	// provider records which CredentialProvider these were loaded from, so a
+	// refreshed token gets written back to the same place. Not persisted.
+	provider string `json:"-"`
+
+	// raw holds the document exactly as it was read from the backend,
+	// including fields this struct doesn't model (scopes, account info,
+	// ...). Store implementations must merge into raw rather than
+	// re-marshaling Credentials, or a refresh silently strips those fields
+	// from the file other Claude tooling reads. Not persisted.
+	raw json.RawMessage `json:"-"`
+}
+
+// mergeCredentialsJSON returns raw with claudeAiOauth's accessToken,
+// refreshToken, and expiresAt replaced by creds' values, leaving every other
+// field (including ones Credentials doesn't model) untouched. If raw is
+// empty, it marshals creds as a fresh document.
+func mergeCredentialsJSON(raw json.RawMessage, creds Credentials) ([]byte, error) {
+	doc := map[string]any{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse existing credentials document: %w", err)
+		}
+	}
+
+	oauth, _ := doc["claudeAiOauth"].(map[string]any)
+	if oauth == nil {
+		oauth = map[string]any{}
+	}
+	oauth["accessToken"] = creds.ClaudeAIOAuth.AccessToken
+	oauth["refreshToken"] = creds.ClaudeAIOAuth.RefreshToken
+	oauth["expiresAt"] = creds.ClaudeAIOAuth.ExpiresAt
+	doc["claudeAiOauth"] = oauth
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// expired reports whether the access token is past its expiry time. A zero
+// ExpiresAt means the credentials predate this field and are assumed valid.
+func (c Credentials) expired(now time.Time) bool {
+	if c.ClaudeAIOAuth.ExpiresAt == 0 {
+		return false
+	}
+	return now.After(time.UnixMilli(c.ClaudeAIOAuth.ExpiresAt))
+}
+
+const (
+	oauthTokenURL      = "https://console.anthropic.com/v1/oauth/token"
+	claudeCodeClientID = "9d1c250a-e61b-44d9-88ed-5944d1962f5e"
+)
+
+// CredentialProvider discovers and persists Claude credentials from one
+// backend (a flat file, an OS keychain, ...). readCredentials tries each
+// registered provider in order until one succeeds.
+type CredentialProvider interface {
+	Name() string
+	Load(ctx context.Context) (Credentials, error)
+	Store(ctx context.Context, c Credentials) error
+}
+
+// providers holds every CredentialProvider built for this platform, keyed by
+// Name(). Platform-specific providers register themselves from init().
+var providers = map[string]CredentialProvider{}
+
+func registerProvider(p CredentialProvider) {
+	providers[p.Name()] = p
+}
+
+func init() {
+	registerProvider(FileProvider{})
+}
+
+// providerOrder returns the provider names to try, in order. It honors
+// CLAUDE_CRED_PROVIDERS (a comma-separated list, e.g. "keychain,file") and
+// otherwise falls back to the platform's defaultProviderOrder.
+func providerOrder() []string {
+	if v := os.Getenv("CLAUDE_CRED_PROVIDERS"); v != "" {
+		parts := strings.Split(v, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		return parts
+	}
+	return defaultProviderOrder
+}
+
+// readCredentials tries each provider in providerOrder, returning the first
+// successful Load and a combined error if none succeed.
+func readCredentials(ctx context.Context) (Credentials, error) {
+	var errs []error
+	for _, name := range providerOrder() {
+		p, ok := providers[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown credential provider", name))
+			continue
+		}
+
+		creds, err := p.Load(ctx)
+		if err == nil {
+			creds.provider = p.Name()
+			return creds, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	return Credentials{}, fmt.Errorf("credentials not found: %w", errors.Join(errs...))
+}
+
+// writeCredentials persists creds back through the provider it was loaded
+// from.
+func writeCredentials(ctx context.Context, creds Credentials) error {
+	p, ok := providers[creds.provider]
+	if !ok {
+		return fmt.Errorf("unknown credential provider %q", creds.provider)
+	}
+	return p.Store(ctx, creds)
+}
+
+// runBounded runs fn in a goroutine and returns ctx.Err() as soon as ctx is
+// done, without waiting for fn. The OS keychain/Secret Service/Credential
+// Manager calls platform providers wrap in this have no context support of
+// their own (a stuck unlock prompt would otherwise block forever), so fn
+// keeps running in the background even after runBounded returns early.
+func runBounded(ctx context.Context, fn func() error) error {
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		return err
+	}
 }
 
 func getCredentialsPath() string {
@@ -22,26 +163,119 @@ func getCredentialsPath() string {
 	return filepath.Join(home, ".claude", ".credentials.json")
 }
 
-// readCredentialsFromFile reads credentials from ~/.claude/.credentials.json
-func readCredentialsFromFile() (string, error) {
+// FileProvider reads and writes ~/.claude/.credentials.json. It is
+// registered on every platform as the universal fallback.
+type FileProvider struct{}
+
+func (FileProvider) Name() string { return "file" }
+
+func (FileProvider) Load(ctx context.Context) (Credentials, error) {
 	credPath := getCredentialsPath()
 	if credPath == "" {
-		return "", fmt.Errorf("could not determine home directory")
+		return Credentials{}, fmt.Errorf("could not determine home directory")
 	}
 
 	data, err := os.ReadFile(credPath)
 	if err != nil {
-		return "", err
+		return Credentials{}, err
 	}
 
 	var creds Credentials
 	if err := json.Unmarshal(data, &creds); err != nil {
-		return "", fmt.Errorf("failed to parse credentials: %w", err)
+		return Credentials{}, fmt.Errorf("failed to parse credentials: %w", err)
 	}
+	creds.raw = data
 
 	if creds.ClaudeAIOAuth.AccessToken == "" {
-		return "", fmt.Errorf("no access token found in credentials")
+		return Credentials{}, fmt.Errorf("no access token found in credentials")
 	}
 
-	return creds.ClaudeAIOAuth.AccessToken, nil
+	return creds, nil
+}
+
+func (FileProvider) Store(ctx context.Context, creds Credentials) error {
+	credPath := getCredentialsPath()
+	if credPath == "" {
+		return fmt.Errorf("could not determine home directory")
+	}
+
+	data, err := mergeCredentialsJSON(creds.raw, creds)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+
+	return os.WriteFile(credPath, data, 0600)
+}
+
+// refreshAccessToken exchanges a refresh token for a new access token against
+// the same OAuth endpoint the Claude CLI itself refreshes against.
+func refreshAccessToken(ctx context.Context, refreshToken string) (newToken, newRefresh string, expiresAt time.Time, err error) {
+	return refreshAccessTokenAtURL(ctx, oauthTokenURL, refreshToken)
+}
+
+// refreshAccessTokenAtURL is refreshAccessToken with the token endpoint
+// broken out so tests can point it at an httptest server.
+func refreshAccessTokenAtURL(ctx context.Context, url, refreshToken string) (newToken, newRefresh string, expiresAt time.Time, err error) {
+	payload, err := json.Marshal(map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": refreshToken,
+		"client_id":     claudeCodeClientID,
+	})
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("failed to build refresh request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("failed to build refresh request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("failed to refresh access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("failed to read refresh response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", time.Time{}, fmt.Errorf("refresh token request returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", "", time.Time{}, fmt.Errorf("failed to parse refresh response: %w", err)
+	}
+
+	return result.AccessToken, result.RefreshToken, time.Now().Add(time.Duration(result.ExpiresIn) * time.Second), nil
+}
+
+// refreshCredentials refreshes creds in place and persists the result
+// through the provider it was loaded from.
+func refreshCredentials(ctx context.Context, creds *Credentials) error {
+	if creds.ClaudeAIOAuth.RefreshToken == "" {
+		return fmt.Errorf("no refresh token available")
+	}
+
+	token, refreshToken, expiresAt, err := refreshAccessToken(ctx, creds.ClaudeAIOAuth.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	creds.ClaudeAIOAuth.AccessToken = token
+	creds.ClaudeAIOAuth.RefreshToken = refreshToken
+	creds.ClaudeAIOAuth.ExpiresAt = expiresAt.UnixMilli()
+
+	if err := writeCredentials(ctx, *creds); err != nil {
+		return fmt.Errorf("refreshed token but failed to persist credentials: %w", err)
+	}
+	return nil
 }
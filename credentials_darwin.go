@@ -3,56 +3,73 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"github.com/keybase/go-keychain"
 )
 
-// readCredentialsFromKeychain reads credentials from macOS Keychain
-func readCredentialsFromKeychain() (string, error) {
-	query := keychain.NewItem()
-	query.SetSecClass(keychain.SecClassGenericPassword)
-	query.SetService("Claude Code-credentials")
-	query.SetMatchLimit(keychain.MatchLimitOne)
-	query.SetReturnData(true)
+// defaultProviderOrder tries the Keychain before falling back to the flat
+// credentials file.
+var defaultProviderOrder = []string{"keychain", "file"}
 
-	results, err := keychain.QueryItem(query)
-	if err != nil {
-		return "", fmt.Errorf("failed to query Keychain: %w", err)
-	}
+func init() {
+	registerProvider(KeychainProvider{})
+}
 
-	if len(results) == 0 {
-		return "", fmt.Errorf("no credentials found in Keychain")
-	}
+// KeychainProvider reads and writes the "Claude Code-credentials" generic
+// password item in the macOS Keychain.
+type KeychainProvider struct{}
 
-	jsonStr := string(results[0].Data)
+func (KeychainProvider) Name() string { return "keychain" }
 
+func (KeychainProvider) Load(ctx context.Context) (Credentials, error) {
 	var creds Credentials
-	if err := json.Unmarshal([]byte(jsonStr), &creds); err != nil {
-		return "", fmt.Errorf("failed to parse Keychain credentials: %w", err)
-	}
+	err := runBounded(ctx, func() error {
+		query := keychain.NewItem()
+		query.SetSecClass(keychain.SecClassGenericPassword)
+		query.SetService("Claude Code-credentials")
+		query.SetMatchLimit(keychain.MatchLimitOne)
+		query.SetReturnData(true)
 
-	if creds.ClaudeAIOAuth.AccessToken == "" {
-		return "", fmt.Errorf("no access token found in Keychain credentials")
-	}
+		results, err := keychain.QueryItem(query)
+		if err != nil {
+			return fmt.Errorf("failed to query Keychain: %w", err)
+		}
+
+		if len(results) == 0 {
+			return fmt.Errorf("no credentials found in Keychain")
+		}
+
+		if err := json.Unmarshal(results[0].Data, &creds); err != nil {
+			return fmt.Errorf("failed to parse Keychain credentials: %w", err)
+		}
+		creds.raw = results[0].Data
 
-	return creds.ClaudeAIOAuth.AccessToken, nil
+		if creds.ClaudeAIOAuth.AccessToken == "" {
+			return fmt.Errorf("no access token found in Keychain credentials")
+		}
+		return nil
+	})
+	return creds, err
 }
 
-// readCredentials tries file first, then Keychain on macOS
-func readCredentials() (string, error) {
-	// Try file-based credentials first
-	token, err := readCredentialsFromFile()
-	if err == nil {
-		return token, nil
+func (KeychainProvider) Store(ctx context.Context, creds Credentials) error {
+	data, err := mergeCredentialsJSON(creds.raw, creds)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
 	}
 
-	// Try Keychain
-	token, err = readCredentialsFromKeychain()
-	if err == nil {
-		return token, nil
-	}
+	return runBounded(ctx, func() error {
+		item := keychain.NewItem()
+		item.SetSecClass(keychain.SecClassGenericPassword)
+		item.SetService("Claude Code-credentials")
+		item.SetData(data)
 
-	return "", fmt.Errorf("credentials not found in file or Keychain: %w", err)
+		if err := keychain.UpdateItem(item, item); err != nil {
+			return fmt.Errorf("failed to update Keychain item: %w", err)
+		}
+		return nil
+	})
 }
@@ -0,0 +1,100 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/keybase/go-keychain/secretservice"
+)
+
+// defaultProviderOrder tries the Secret Service before falling back to the
+// flat credentials file.
+var defaultProviderOrder = []string{"secretservice", "file"}
+
+func init() {
+	registerProvider(SecretServiceProvider{})
+}
+
+// secretServiceAttributes identifies the item Claude Code itself stores in
+// the "login" collection.
+var secretServiceAttributes = secretservice.Attributes{"service": "Claude Code-credentials"}
+
+// SecretServiceProvider reads and writes credentials via the freedesktop.org
+// Secret Service (GNOME Keyring, KWallet, ...) over D-Bus.
+type SecretServiceProvider struct{}
+
+func (SecretServiceProvider) Name() string { return "secretservice" }
+
+func (SecretServiceProvider) Load(ctx context.Context) (Credentials, error) {
+	var creds Credentials
+	err := runBounded(ctx, func() error {
+		srv, err := secretservice.NewService()
+		if err != nil {
+			return fmt.Errorf("failed to connect to Secret Service: %w", err)
+		}
+
+		session, err := srv.OpenSession(secretservice.AuthenticationDHAES)
+		if err != nil {
+			return fmt.Errorf("failed to open Secret Service session: %w", err)
+		}
+		defer srv.CloseSession(session)
+
+		items, err := srv.SearchCollection(secretservice.DefaultCollection, secretServiceAttributes)
+		if err != nil {
+			return fmt.Errorf("failed to search Secret Service collection: %w", err)
+		}
+		if len(items) == 0 {
+			return fmt.Errorf("no credentials found in Secret Service")
+		}
+
+		secret, err := srv.GetSecret(items[0], *session)
+		if err != nil {
+			return fmt.Errorf("failed to read Secret Service item: %w", err)
+		}
+
+		if err := json.Unmarshal(secret, &creds); err != nil {
+			return fmt.Errorf("failed to parse Secret Service credentials: %w", err)
+		}
+		creds.raw = secret
+
+		if creds.ClaudeAIOAuth.AccessToken == "" {
+			return fmt.Errorf("no access token found in Secret Service credentials")
+		}
+		return nil
+	})
+	return creds, err
+}
+
+func (SecretServiceProvider) Store(ctx context.Context, creds Credentials) error {
+	data, err := mergeCredentialsJSON(creds.raw, creds)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+
+	return runBounded(ctx, func() error {
+		srv, err := secretservice.NewService()
+		if err != nil {
+			return fmt.Errorf("failed to connect to Secret Service: %w", err)
+		}
+
+		session, err := srv.OpenSession(secretservice.AuthenticationDHAES)
+		if err != nil {
+			return fmt.Errorf("failed to open Secret Service session: %w", err)
+		}
+		defer srv.CloseSession(session)
+
+		secret, err := session.NewSecret(data)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt Secret Service payload: %w", err)
+		}
+
+		properties := secretservice.NewSecretProperties("Claude Code-credentials", secretServiceAttributes)
+		if _, err := srv.CreateItem(secretservice.DefaultCollection, properties, secret, secretservice.ReplaceBehaviorReplace); err != nil {
+			return fmt.Errorf("failed to update Secret Service item: %w", err)
+		}
+		return nil
+	})
+}
@@ -1,15 +1,7 @@
-//go:build !darwin
+//go:build !darwin && !linux && !windows
 
 package main
 
-import "fmt"
-
-// readCredentials reads credentials from file (non-macOS)
-func readCredentials() (string, error) {
-	token, err := readCredentialsFromFile()
-	if err != nil {
-		credPath := getCredentialsPath()
-		return "", fmt.Errorf("credentials not found at %s: %w", credPath, err)
-	}
-	return token, nil
-}
+// defaultProviderOrder on unrecognized platforms falls back to the flat
+// credentials file only.
+var defaultProviderOrder = []string{"file"}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestProviderOrderEnvOverride(t *testing.T) {
+	t.Setenv("CLAUDE_CRED_PROVIDERS", "keychain, file ,secretservice")
+
+	got := providerOrder()
+	want := []string{"keychain", "file", "secretservice"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("providerOrder() = %v, want %v", got, want)
+	}
+}
+
+func TestProviderOrderDefaultWhenUnset(t *testing.T) {
+	t.Setenv("CLAUDE_CRED_PROVIDERS", "")
+
+	got := providerOrder()
+	if !reflect.DeepEqual(got, defaultProviderOrder) {
+		t.Errorf("providerOrder() = %v, want defaultProviderOrder %v", got, defaultProviderOrder)
+	}
+}
+
+// fakeProvider is a CredentialProvider stand-in for exercising the
+// readCredentials fallback chain without touching a real backend.
+type fakeProvider struct {
+	name    string
+	creds   Credentials
+	loadErr error
+}
+
+func (f fakeProvider) Name() string { return f.name }
+
+func (f fakeProvider) Load(ctx context.Context) (Credentials, error) {
+	if f.loadErr != nil {
+		return Credentials{}, f.loadErr
+	}
+	return f.creds, nil
+}
+
+func (f fakeProvider) Store(ctx context.Context, c Credentials) error {
+	return nil
+}
+
+// withProviders temporarily swaps the global provider registry and restores
+// it when the test completes.
+func withProviders(t *testing.T, ps ...CredentialProvider) {
+	t.Helper()
+	original := providers
+	providers = map[string]CredentialProvider{}
+	for _, p := range ps {
+		registerProvider(p)
+	}
+	t.Cleanup(func() { providers = original })
+}
+
+func TestReadCredentialsFallsBackToNextProvider(t *testing.T) {
+	var secondCreds Credentials
+	secondCreds.ClaudeAIOAuth.AccessToken = "second-token"
+
+	withProviders(t,
+		fakeProvider{name: "first", loadErr: fmt.Errorf("not found")},
+		fakeProvider{name: "second", creds: secondCreds},
+	)
+	t.Setenv("CLAUDE_CRED_PROVIDERS", "first,second")
+
+	creds, err := readCredentials(context.Background())
+	if err != nil {
+		t.Fatalf("readCredentials failed: %v", err)
+	}
+	if creds.ClaudeAIOAuth.AccessToken != "second-token" {
+		t.Errorf("expected token from 'second' provider, got %q", creds.ClaudeAIOAuth.AccessToken)
+	}
+	if creds.provider != "second" {
+		t.Errorf("expected provider 'second', got %q", creds.provider)
+	}
+}
+
+func TestReadCredentialsAllProvidersFail(t *testing.T) {
+	withProviders(t,
+		fakeProvider{name: "first", loadErr: fmt.Errorf("not found")},
+		fakeProvider{name: "second", loadErr: fmt.Errorf("not found")},
+	)
+	t.Setenv("CLAUDE_CRED_PROVIDERS", "first,second")
+
+	if _, err := readCredentials(context.Background()); err == nil {
+		t.Fatal("expected error when every provider fails, got nil")
+	}
+}
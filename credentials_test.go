@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRefreshAccessTokenSuccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST request, got %s", r.Method)
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected Content-Type: application/json, got %s", r.Header.Get("Content-Type"))
+		}
+
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		if body["grant_type"] != "refresh_token" {
+			t.Errorf("expected grant_type refresh_token, got %s", body["grant_type"])
+		}
+		if body["refresh_token"] != "old-refresh" {
+			t.Errorf("expected refresh_token old-refresh, got %s", body["refresh_token"])
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"new-access","refresh_token":"new-refresh","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	token, refreshToken, expiresAt, err := refreshAccessTokenAtURL(context.Background(), server.URL, "old-refresh")
+	if err != nil {
+		t.Fatalf("refreshAccessTokenAtURL failed: %v", err)
+	}
+	if token != "new-access" {
+		t.Errorf("expected access token 'new-access', got %q", token)
+	}
+	if refreshToken != "new-refresh" {
+		t.Errorf("expected refresh token 'new-refresh', got %q", refreshToken)
+	}
+	if !expiresAt.After(time.Now()) {
+		t.Errorf("expected expiresAt in the future, got %v", expiresAt)
+	}
+}
+
+func TestRefreshAccessTokenNon200(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant"}`))
+	}))
+	defer server.Close()
+
+	_, _, _, err := refreshAccessTokenAtURL(context.Background(), server.URL, "old-refresh")
+	if err == nil {
+		t.Fatal("expected error for non-200 response, got nil")
+	}
+}
+
+func TestRefreshAccessTokenMalformedJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`not json`))
+	}))
+	defer server.Close()
+
+	_, _, _, err := refreshAccessTokenAtURL(context.Background(), server.URL, "old-refresh")
+	if err == nil {
+		t.Fatal("expected error for malformed JSON response, got nil")
+	}
+}
+
+func TestRefreshCredentialsMissingRefreshToken(t *testing.T) {
+	creds := &Credentials{}
+	creds.provider = "file"
+
+	if err := refreshCredentials(context.Background(), creds); err == nil {
+		t.Fatal("expected error when no refresh token is available, got nil")
+	}
+}
+
+func TestMergeCredentialsJSONPreservesUnknownFields(t *testing.T) {
+	raw := json.RawMessage(`{
+		"claudeAiOauth": {
+			"accessToken": "old-access",
+			"refreshToken": "old-refresh",
+			"expiresAt": 1000,
+			"scopes": ["user:inference"]
+		},
+		"accountInfo": {"email": "someone@example.com"}
+	}`)
+
+	var creds Credentials
+	creds.ClaudeAIOAuth.AccessToken = "new-access"
+	creds.ClaudeAIOAuth.RefreshToken = "new-refresh"
+	creds.ClaudeAIOAuth.ExpiresAt = 2000
+
+	merged, err := mergeCredentialsJSON(raw, creds)
+	if err != nil {
+		t.Fatalf("mergeCredentialsJSON failed: %v", err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(merged, &doc); err != nil {
+		t.Fatalf("failed to parse merged document: %v", err)
+	}
+
+	oauth, ok := doc["claudeAiOauth"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected claudeAiOauth to be an object, got %T", doc["claudeAiOauth"])
+	}
+	if oauth["accessToken"] != "new-access" {
+		t.Errorf("expected accessToken 'new-access', got %v", oauth["accessToken"])
+	}
+	if oauth["refreshToken"] != "new-refresh" {
+		t.Errorf("expected refreshToken 'new-refresh', got %v", oauth["refreshToken"])
+	}
+	if oauth["expiresAt"] != float64(2000) {
+		t.Errorf("expected expiresAt 2000, got %v", oauth["expiresAt"])
+	}
+	if scopes, ok := oauth["scopes"].([]any); !ok || len(scopes) != 1 || scopes[0] != "user:inference" {
+		t.Errorf("expected scopes to survive the merge unchanged, got %v", oauth["scopes"])
+	}
+
+	accountInfo, ok := doc["accountInfo"].(map[string]any)
+	if !ok || accountInfo["email"] != "someone@example.com" {
+		t.Errorf("expected accountInfo to survive the merge unchanged, got %v", doc["accountInfo"])
+	}
+}
+
+func TestFileProviderStorePreservesUnknownFields(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	credDir := tmpDir + "/.claude"
+	if err := os.MkdirAll(credDir, 0700); err != nil {
+		t.Fatalf("failed to create credentials dir: %v", err)
+	}
+	credPath := credDir + "/.credentials.json"
+	original := []byte(`{"claudeAiOauth":{"accessToken":"old-access","refreshToken":"old-refresh","expiresAt":1000,"scopes":["user:inference"]},"accountInfo":{"email":"someone@example.com"}}`)
+	if err := os.WriteFile(credPath, original, 0600); err != nil {
+		t.Fatalf("failed to seed credentials file: %v", err)
+	}
+
+	creds, err := (FileProvider{}).Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	creds.ClaudeAIOAuth.AccessToken = "new-access"
+	creds.ClaudeAIOAuth.RefreshToken = "new-refresh"
+	if err := (FileProvider{}).Store(context.Background(), creds); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	data, err := os.ReadFile(credPath)
+	if err != nil {
+		t.Fatalf("failed to read back credentials file: %v", err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("failed to parse stored document: %v", err)
+	}
+	if _, ok := doc["accountInfo"]; !ok {
+		t.Error("expected accountInfo to survive Store, but it was dropped")
+	}
+	oauth, _ := doc["claudeAiOauth"].(map[string]any)
+	if oauth["accessToken"] != "new-access" {
+		t.Errorf("expected accessToken 'new-access', got %v", oauth["accessToken"])
+	}
+	if _, ok := oauth["scopes"]; !ok {
+		t.Error("expected scopes to survive Store, but it was dropped")
+	}
+}
+
+func TestRunBoundedReturnsCtxErrWhileFnBlocks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := runBounded(ctx, func() error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestRunBoundedReturnsFnResultWhenFasterThanCtx(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := runBounded(ctx, func() error { return errFnDone })
+	if err != errFnDone {
+		t.Fatalf("expected errFnDone, got %v", err)
+	}
+}
+
+var errFnDone = errors.New("fn done")
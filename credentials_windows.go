@@ -0,0 +1,64 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/danieljoos/wincred"
+)
+
+// defaultProviderOrder tries Windows Credential Manager before falling back
+// to the flat credentials file.
+var defaultProviderOrder = []string{"wcm", "file"}
+
+const wcmTargetName = "Claude Code-credentials"
+
+func init() {
+	registerProvider(WCMProvider{})
+}
+
+// WCMProvider reads and writes the generic credential Claude Code stores in
+// Windows Credential Manager.
+type WCMProvider struct{}
+
+func (WCMProvider) Name() string { return "wcm" }
+
+func (WCMProvider) Load(ctx context.Context) (Credentials, error) {
+	var creds Credentials
+	err := runBounded(ctx, func() error {
+		cred, err := wincred.GetGenericCredential(wcmTargetName)
+		if err != nil {
+			return fmt.Errorf("failed to read Credential Manager entry: %w", err)
+		}
+
+		if err := json.Unmarshal(cred.CredentialBlob, &creds); err != nil {
+			return fmt.Errorf("failed to parse Credential Manager credentials: %w", err)
+		}
+		creds.raw = cred.CredentialBlob
+
+		if creds.ClaudeAIOAuth.AccessToken == "" {
+			return fmt.Errorf("no access token found in Credential Manager credentials")
+		}
+		return nil
+	})
+	return creds, err
+}
+
+func (WCMProvider) Store(ctx context.Context, creds Credentials) error {
+	data, err := mergeCredentialsJSON(creds.raw, creds)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+
+	return runBounded(ctx, func() error {
+		cred := wincred.NewGenericCredential(wcmTargetName)
+		cred.CredentialBlob = data
+		if err := cred.Write(); err != nil {
+			return fmt.Errorf("failed to update Credential Manager entry: %w", err)
+		}
+		return nil
+	})
+}
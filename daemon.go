@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kylemclaren/claude-usage-status/internal/ipc"
+)
+
+// ipcTimeout bounds how long a single daemon<->client frame may take to
+// read or write.
+const ipcTimeout = 2 * time.Second
+
+// maxBackoffMultiplier caps how far runDaemon backs off between failed
+// polls, as a multiple of the poll interval.
+const maxBackoffMultiplier = 10
+
+// runDaemon polls fetchUsage every interval, caching the latest result in
+// memory, and serves it to --client invocations over ipc.SocketPath. It
+// blocks until ctx is canceled (SIGTERM/SIGINT) or the listener fails. Each
+// poll is bounded by timeout, independent of the daemon's own lifetime.
+func runDaemon(parent context.Context, creds Credentials, interval, timeout time.Duration) error {
+	return runDaemonWithFetch(parent, creds, interval, timeout, fetchUsage)
+}
+
+// runDaemonWithFetch is runDaemon with the usage fetch broken out so tests
+// can drive a poll with a stub instead of the real API.
+func runDaemonWithFetch(parent context.Context, creds Credentials, interval, timeout time.Duration, fetch func(ctx context.Context, token string) (*UsageResponse, error)) error {
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ln, err := ipc.Listen()
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", ipc.SocketPath(), err)
+	}
+	defer ipc.Remove()
+
+	var (
+		mu        sync.Mutex
+		cached    *UsageResponse
+		fetchedAt time.Time
+	)
+
+	poll := func() error {
+		fetchCtx, cancel := context.WithTimeout(ctx, timeout)
+		usage, err := fetch(fetchCtx, creds.ClaudeAIOAuth.AccessToken)
+		cancel()
+
+		if errors.Is(err, errUnauthorized) {
+			refreshCtx, rcancel := context.WithTimeout(ctx, timeout)
+			refreshErr := refreshCredentials(refreshCtx, &creds)
+			rcancel()
+
+			if refreshErr == nil {
+				retryCtx, rcancel := context.WithTimeout(ctx, timeout)
+				usage, err = fetch(retryCtx, creds.ClaudeAIOAuth.AccessToken)
+				rcancel()
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		cached = usage
+		fetchedAt = time.Now()
+		mu.Unlock()
+		return nil
+	}
+
+	if err := poll(); err != nil {
+		log.Printf("claude-usage: initial poll failed: %v", err)
+	}
+
+	go func() {
+		backoff := interval
+		timer := time.NewTimer(backoff)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+
+			if err := poll(); err != nil {
+				log.Printf("claude-usage: poll failed, backing off: %v", err)
+				backoff *= 2
+				if backoff > maxBackoffMultiplier*interval {
+					backoff = maxBackoffMultiplier * interval
+				}
+			} else {
+				backoff = interval
+			}
+			timer.Reset(backoff)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("accept failed: %w", err)
+		}
+
+		go serveStatus(conn, &mu, &cached, &fetchedAt, interval)
+	}
+}
+
+// serveStatus answers a single --client request with the currently cached
+// usage snapshot.
+func serveStatus(conn net.Conn, mu *sync.Mutex, cached **UsageResponse, fetchedAt *time.Time, interval time.Duration) {
+	defer conn.Close()
+
+	var req ipc.Request
+	if err := ipc.ReadFramed(conn, &req, ipcTimeout); err != nil {
+		return
+	}
+
+	mu.Lock()
+	resp := ipc.Response{
+		FetchedAt: *fetchedAt,
+		Stale:     time.Since(*fetchedAt) > 2*interval,
+	}
+	if *cached != nil {
+		if body, err := json.Marshal(*cached); err == nil {
+			resp.Usage = body
+		}
+	}
+	mu.Unlock()
+
+	_ = ipc.WriteFramed(conn, resp, ipcTimeout)
+}
+
+// runClient reads the cached status from a running --daemon and prints it,
+// returning well under the poll interval even if the network is down.
+func runClient(ctx context.Context) error {
+	conn, err := ipc.Dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon at %s: %w", ipc.SocketPath(), err)
+	}
+	defer conn.Close()
+
+	if err := ipc.WriteFramed(conn, ipc.Request{Type: "get_status"}, ipcTimeout); err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	var resp ipc.Response
+	if err := ipc.ReadFramed(conn, &resp, ipcTimeout); err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.Error != "" {
+		return fmt.Errorf("daemon error: %s", resp.Error)
+	}
+	if resp.Usage == nil {
+		return fmt.Errorf("daemon has no cached usage yet")
+	}
+
+	var usage UsageResponse
+	if err := json.Unmarshal(resp.Usage, &usage); err != nil {
+		return fmt.Errorf("failed to parse daemon response: %w", err)
+	}
+
+	fmt.Println(formatStatusLine(&usage, time.Now()))
+	return nil
+}
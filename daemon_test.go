@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/kylemclaren/claude-usage-status/internal/ipc"
+)
+
+func TestRunDaemonServesPolledStatusOverIPC(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	stubUsage := &UsageResponse{
+		FiveHour: UsageBucket{Utilization: 42, ResetsAt: "2026-01-09T15:00:00Z"},
+		SevenDay: UsageBucket{Utilization: 7, ResetsAt: "2026-01-16T00:00:00Z"},
+	}
+	fetch := func(ctx context.Context, token string) (*UsageResponse, error) {
+		return stubUsage, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runDaemonWithFetch(ctx, Credentials{}, time.Hour, 2*time.Second, fetch)
+	}()
+
+	conn := dialDaemonWithRetry(t, 2*time.Second)
+	defer conn.Close()
+
+	if err := ipc.WriteFramed(conn, ipc.Request{Type: "get_status"}, time.Second); err != nil {
+		t.Fatalf("failed to write request: %v", err)
+	}
+
+	var resp ipc.Response
+	if err := ipc.ReadFramed(conn, &resp, time.Second); err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	if resp.Error != "" {
+		t.Fatalf("daemon returned error: %s", resp.Error)
+	}
+
+	var usage UsageResponse
+	if err := json.Unmarshal(resp.Usage, &usage); err != nil {
+		t.Fatalf("failed to parse usage from response: %v", err)
+	}
+	if usage.FiveHour.Utilization != 42 {
+		t.Errorf("expected FiveHour.Utilization 42, got %v", usage.FiveHour.Utilization)
+	}
+	if usage.SevenDay.Utilization != 7 {
+		t.Errorf("expected SevenDay.Utilization 7, got %v", usage.SevenDay.Utilization)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("runDaemonWithFetch returned error after shutdown: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("runDaemonWithFetch did not exit after context cancellation")
+	}
+}
+
+// dialDaemonWithRetry dials the daemon socket, retrying until it comes up or
+// timeout elapses, since runDaemonWithFetch starts listening asynchronously
+// from the caller's perspective.
+func dialDaemonWithRetry(t *testing.T, timeout time.Duration) net.Conn {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	var lastErr error
+	for time.Now().Before(deadline) {
+		dialCtx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		conn, err := ipc.Dial(dialCtx)
+		cancel()
+		if err == nil {
+			return conn
+		}
+		lastErr = err
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("failed to dial daemon socket: %v", lastErr)
+	return nil
+}
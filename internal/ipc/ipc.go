@@ -0,0 +1,42 @@
+// Package ipc implements the small framed-JSON protocol used between the
+// claude-usage daemon and its --client invocations.
+package ipc
+
+import (
+	"encoding/json"
+	"net"
+	"time"
+)
+
+// Request is sent by a --client to ask the daemon for its cached status.
+type Request struct {
+	Type string `json:"type"`
+}
+
+// Response carries the daemon's last-known usage snapshot. Usage is left as
+// raw JSON so this package doesn't need to depend on the caller's usage
+// response type.
+type Response struct {
+	Usage     json.RawMessage `json:"usage,omitempty"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	Stale     bool            `json:"stale"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// WriteFramed encodes v as a single newline-delimited JSON frame, applying a
+// write deadline so a wedged client can never block the daemon forever.
+func WriteFramed(conn net.Conn, v any, timeout time.Duration) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	return json.NewEncoder(conn).Encode(v)
+}
+
+// ReadFramed decodes a single newline-delimited JSON frame into v, applying
+// a read deadline so a wedged peer can never block the reader forever.
+func ReadFramed(conn net.Conn, v any, timeout time.Duration) error {
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	return json.NewDecoder(conn).Decode(v)
+}
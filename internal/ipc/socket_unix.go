@@ -0,0 +1,38 @@
+//go:build !windows
+
+package ipc
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// SocketPath returns the Unix domain socket path the daemon listens on.
+func SocketPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "claude-usage.sock")
+}
+
+// Listen starts listening on SocketPath, clearing a stale socket left behind
+// by an unclean shutdown.
+func Listen() (net.Listener, error) {
+	path := SocketPath()
+	os.Remove(path)
+	return net.Listen("unix", path)
+}
+
+// Dial connects to a running daemon's socket.
+func Dial(ctx context.Context) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, "unix", SocketPath())
+}
+
+// Remove deletes the socket file, intended for use during graceful shutdown.
+func Remove() {
+	os.Remove(SocketPath())
+}
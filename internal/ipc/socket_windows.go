@@ -0,0 +1,31 @@
+//go:build windows
+
+package ipc
+
+import (
+	"context"
+	"net"
+
+	"github.com/Microsoft/go-winio"
+)
+
+// pipeName is the named pipe the daemon listens on, Windows' analogue of the
+// Unix domain socket used on other platforms.
+const pipeName = `\\.\pipe\claude-usage`
+
+// SocketPath returns the named pipe path the daemon listens on.
+func SocketPath() string { return pipeName }
+
+// Listen starts listening on the named pipe.
+func Listen() (net.Listener, error) {
+	return winio.ListenPipe(pipeName, nil)
+}
+
+// Dial connects to a running daemon's named pipe.
+func Dial(ctx context.Context) (net.Conn, error) {
+	return winio.DialPipeContext(ctx, pipeName)
+}
+
+// Remove is a no-op on Windows: the OS reclaims the named pipe once every
+// handle to it is closed.
+func Remove() {}
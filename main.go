@@ -1,25 +1,17 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"runtime"
-	"strings"
 	"time"
 )
 
-// Credentials represents the structure of ~/.claude/.credentials.json
-type Credentials struct {
-	ClaudeAIOAuth struct {
-		AccessToken string `json:"accessToken"`
-	} `json:"claudeAiOauth"`
-}
-
 // UsageBucket represents a usage time bucket (five_hour or seven_day)
 type UsageBucket struct {
 	Utilization float64 `json:"utilization"`
@@ -34,90 +26,14 @@ type UsageResponse struct {
 
 const usageAPIURL = "https://api.anthropic.com/api/oauth/usage"
 
-func getCredentialsPath() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return ""
-	}
-	return filepath.Join(home, ".claude", ".credentials.json")
-}
-
-// readCredentialsFromFile reads credentials from ~/.claude/.credentials.json (Linux)
-func readCredentialsFromFile() (string, error) {
-	credPath := getCredentialsPath()
-	if credPath == "" {
-		return "", fmt.Errorf("could not determine home directory")
-	}
-
-	data, err := os.ReadFile(credPath)
-	if err != nil {
-		return "", err
-	}
-
-	var creds Credentials
-	if err := json.Unmarshal(data, &creds); err != nil {
-		return "", fmt.Errorf("failed to parse credentials: %w", err)
-	}
-
-	if creds.ClaudeAIOAuth.AccessToken == "" {
-		return "", fmt.Errorf("no access token found in credentials")
-	}
-
-	return creds.ClaudeAIOAuth.AccessToken, nil
-}
-
-// readCredentialsFromKeychain reads credentials from macOS Keychain
-func readCredentialsFromKeychain() (string, error) {
-	// Use security command to read from Keychain
-	cmd := exec.Command("security", "find-generic-password",
-		"-s", "Claude Code-credentials",
-		"-w")
-
-	output, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("failed to read from Keychain: %w", err)
-	}
-
-	// The output is the password (JSON string), parse it
-	jsonStr := strings.TrimSpace(string(output))
-
-	var creds Credentials
-	if err := json.Unmarshal([]byte(jsonStr), &creds); err != nil {
-		return "", fmt.Errorf("failed to parse Keychain credentials: %w", err)
-	}
-
-	if creds.ClaudeAIOAuth.AccessToken == "" {
-		return "", fmt.Errorf("no access token found in Keychain credentials")
-	}
-
-	return creds.ClaudeAIOAuth.AccessToken, nil
-}
-
-// readCredentials tries to read credentials from file first, then Keychain (macOS)
-func readCredentials() (string, error) {
-	// Try file-based credentials first (Linux and some configurations)
-	token, err := readCredentialsFromFile()
-	if err == nil {
-		return token, nil
-	}
-
-	// On macOS, try Keychain
-	if runtime.GOOS == "darwin" {
-		token, err := readCredentialsFromKeychain()
-		if err == nil {
-			return token, nil
-		}
-		return "", fmt.Errorf("credentials not found in file or Keychain: %w", err)
-	}
-
-	// Return the original file error for non-macOS
-	credPath := getCredentialsPath()
-	return "", fmt.Errorf("credentials not found at %s", credPath)
-}
+// errUnauthorized indicates the API rejected the access token, which callers
+// of fetchUsage (main and the daemon's poll) use as the signal to refresh and
+// retry once.
+var errUnauthorized = errors.New("unauthorized")
 
 // fetchUsageFromURL fetches usage data from a specified URL (for testing)
-func fetchUsageFromURL(url, token string) (*UsageResponse, error) {
-	req, err := http.NewRequest("GET", url, nil)
+func fetchUsageFromURL(ctx context.Context, url, token string) (*UsageResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -133,6 +49,11 @@ func fetchUsageFromURL(url, token string) (*UsageResponse, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%w: %s", errUnauthorized, string(body))
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
@@ -152,8 +73,8 @@ func fetchUsageFromURL(url, token string) (*UsageResponse, error) {
 }
 
 // fetchUsage fetches usage data from the Anthropic API
-func fetchUsage(token string) (*UsageResponse, error) {
-	return fetchUsageFromURL(usageAPIURL, token)
+func fetchUsage(ctx context.Context, token string) (*UsageResponse, error) {
+	return fetchUsageFromURL(ctx, usageAPIURL, token)
 }
 
 // ANSI color codes for gradient progress bar
@@ -279,14 +200,86 @@ func formatStatusLine(usage *UsageResponse, now time.Time) string {
 		fiveHourReset)
 }
 
+// defaultTimeout is how long credential reads and API calls are allowed to
+// take before main gives up, overridable with --timeout or
+// CLAUDE_USAGE_TIMEOUT.
+const defaultTimeout = 5 * time.Second
+
+func timeoutFromEnv() time.Duration {
+	if v := os.Getenv("CLAUDE_USAGE_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return defaultTimeout
+}
+
 func main() {
-	token, err := readCredentials()
+	if len(os.Args) >= 3 && os.Args[1] == "cache" && os.Args[2] == "clear" {
+		if err := clearCache(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	daemonMode := flag.Bool("daemon", false, "poll usage in the background and serve cached status over a local socket")
+	clientMode := flag.Bool("client", false, "print cached status from a running --daemon instead of calling the API directly")
+	interval := flag.Duration("interval", 60*time.Second, "how often --daemon polls the usage API")
+	timeout := flag.Duration("timeout", timeoutFromEnv(), "timeout for credential reads and API calls (overridable via CLAUDE_USAGE_TIMEOUT)")
+	noCache := flag.Bool("no-cache", false, "bypass the on-disk usage cache")
+	minRefresh := flag.Duration("min-refresh", 30*time.Second, "minimum time between real API calls; repeated checks inside this window are served from the cache")
+	flag.Parse()
+
+	if *clientMode {
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+		if err := runClient(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	credCtx, cancel := context.WithTimeout(context.Background(), *timeout)
+	creds, err := readCredentials(credCtx)
+	cancel()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	usage, err := fetchUsage(token)
+	if creds.expired(time.Now()) {
+		refreshCtx, cancel := context.WithTimeout(context.Background(), *timeout)
+		err := refreshCredentials(refreshCtx, &creds)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *daemonMode {
+		if err := runDaemon(context.Background(), creds, *interval, *timeout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fetchCtx, cancel := context.WithTimeout(context.Background(), *timeout)
+	usage, err := fetchUsageCached(fetchCtx, creds.ClaudeAIOAuth.AccessToken, *minRefresh, *noCache)
+	cancel()
+	if errors.Is(err, errUnauthorized) {
+		refreshCtx, cancel := context.WithTimeout(context.Background(), *timeout)
+		refreshErr := refreshCredentials(refreshCtx, &creds)
+		cancel()
+		if refreshErr == nil {
+			retryCtx, cancel := context.WithTimeout(context.Background(), *timeout)
+			usage, err = fetchUsageCached(retryCtx, creds.ClaudeAIOAuth.AccessToken, *minRefresh, *noCache)
+			cancel()
+		}
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -166,7 +169,7 @@ func TestFetchUsageWithMockServer(t *testing.T) {
 	defer server.Close()
 
 	// Test fetchUsageFromURL helper (we'll add this function)
-	usage, err := fetchUsageFromURL(server.URL, "test-token-123")
+	usage, err := fetchUsageFromURL(context.Background(), server.URL, "test-token-123")
 	if err != nil {
 		t.Fatalf("fetchUsageFromURL failed: %v", err)
 	}
@@ -187,35 +190,33 @@ func TestFetchUsageAPIError(t *testing.T) {
 	}))
 	defer server.Close()
 
-	_, err := fetchUsageFromURL(server.URL, "bad-token")
+	_, err := fetchUsageFromURL(context.Background(), server.URL, "bad-token")
 	if err == nil {
 		t.Error("expected error for unauthorized request")
 	}
 }
 
-func TestGetColorEmoji(t *testing.T) {
-	tests := []struct {
-		name        string
-		utilization float64
-		want        string
-	}{
-		{"zero usage", 0.0, "游릭"},
-		{"low usage", 0.45, "游릭"},
-		{"below threshold", 0.69, "游릭"},
-		{"at yellow threshold", 0.70, "游리"},
-		{"medium usage", 0.78, "游리"},
-		{"at red threshold", 0.90, "游리"},
-		{"high usage", 0.91, "游댮"},
-		{"max usage", 1.0, "游댮"},
-	}
+func TestFetchUsageFromURLTimeout(t *testing.T) {
+	block := make(chan struct{})
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := getColorEmoji(tt.utilization)
-			if got != tt.want {
-				t.Errorf("getColorEmoji(%v) = %v, want %v", tt.utilization, got, tt.want)
-			}
-		})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer server.Close()
+	defer close(block)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := fetchUsageFromURL(ctx, server.URL, "test-token")
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed > time.Second {
+		t.Errorf("fetchUsageFromURL took %v, expected to return within the configured timeout", elapsed)
 	}
 }
 
@@ -272,39 +273,41 @@ func TestFormatStatusLine(t *testing.T) {
 	tests := []struct {
 		name  string
 		usage *UsageResponse
-		want  string
+		want  []string
 	}{
 		{
 			name: "low usage both",
 			usage: &UsageResponse{
-				FiveHour: UsageBucket{Utilization: 0.45, ResetsAt: "2026-01-09T15:00:00Z"},
-				SevenDay: UsageBucket{Utilization: 0.30, ResetsAt: "2026-01-16T00:00:00Z"},
+				FiveHour: UsageBucket{Utilization: 45, ResetsAt: "2026-01-09T15:00:00Z"},
+				SevenDay: UsageBucket{Utilization: 30, ResetsAt: "2026-01-16T00:00:00Z"},
 			},
-			want: "游릭 5h:45% | 游릭 7d:30% | resets 2h15m",
+			want: []string{"5h", "45%", "7d", "30%", "2h15m"},
 		},
 		{
 			name: "mixed usage",
 			usage: &UsageResponse{
-				FiveHour: UsageBucket{Utilization: 0.45, ResetsAt: "2026-01-09T15:00:00Z"},
-				SevenDay: UsageBucket{Utilization: 0.78, ResetsAt: "2026-01-16T00:00:00Z"},
+				FiveHour: UsageBucket{Utilization: 45, ResetsAt: "2026-01-09T15:00:00Z"},
+				SevenDay: UsageBucket{Utilization: 78, ResetsAt: "2026-01-16T00:00:00Z"},
 			},
-			want: "游릭 5h:45% | 游리 7d:78% | resets 2h15m",
+			want: []string{"5h", "45%", "7d", "78%", "2h15m"},
 		},
 		{
 			name: "high usage both",
 			usage: &UsageResponse{
-				FiveHour: UsageBucket{Utilization: 0.95, ResetsAt: "2026-01-09T15:00:00Z"},
-				SevenDay: UsageBucket{Utilization: 0.92, ResetsAt: "2026-01-16T00:00:00Z"},
+				FiveHour: UsageBucket{Utilization: 95, ResetsAt: "2026-01-09T15:00:00Z"},
+				SevenDay: UsageBucket{Utilization: 92, ResetsAt: "2026-01-16T00:00:00Z"},
 			},
-			want: "游댮 5h:95% | 游댮 7d:92% | resets 2h15m",
+			want: []string{"5h", "95%", "7d", "92%", "2h15m"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got := formatStatusLine(tt.usage, now)
-			if got != tt.want {
-				t.Errorf("formatStatusLine() = %v, want %v", got, tt.want)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("formatStatusLine() = %q, expected to contain %q", got, want)
+				}
 			}
 		})
 	}